@@ -0,0 +1,33 @@
+package daemon
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics are the Prometheus counters/gauges exposed on /metrics. They're
+// package-level like any other prometheus collector, so every part of the
+// daemon loop can record against them without threading a struct through.
+var (
+	ClonesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "go_git_repos_clones_total",
+		Help: "Total number of clone attempts, failed ones included.",
+	})
+	FetchesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "go_git_repos_fetches_total",
+		Help: "Total number of fetch attempts, failed ones included.",
+	})
+	FailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "go_git_repos_failures_total",
+		Help: "Total number of clone/fetch failures.",
+	})
+	RepoCount = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "go_git_repos_repo_count",
+		Help: "Number of repos currently tracked.",
+	})
+	LastSyncAgeSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "go_git_repos_last_sync_age_seconds",
+		Help: "Seconds since the least-recently-synced repo was last recorded.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(ClonesTotal, FetchesTotal, FailuresTotal, RepoCount, LastSyncAgeSeconds)
+}
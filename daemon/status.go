@@ -0,0 +1,77 @@
+// Package daemon holds the bits a long-running backup pass needs that a
+// one-shot run doesn't: tracking per-repo sync outcomes and serving them
+// over HTTP for /status and /metrics.
+package daemon
+
+import (
+	"sync"
+	"time"
+)
+
+// RepoSync records the outcome of the most recent sync attempt for one repo.
+type RepoSync struct {
+	Name     string    `json:"name"`
+	LastSync time.Time `json:"last_sync"`
+	Duration string    `json:"duration"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Status is a thread-safe record of every repo's most recent sync, kept
+// fresh across daemon passes for the /status endpoint.
+type Status struct {
+	mu    sync.RWMutex
+	repos map[string]RepoSync
+}
+
+func NewStatus() *Status {
+	return &Status{repos: make(map[string]RepoSync)}
+}
+
+// Record stores the outcome of syncing repo name.
+func (s *Status) Record(name string, dur time.Duration, err error) {
+	rs := RepoSync{
+		Name:     name,
+		LastSync: time.Now(),
+		Duration: dur.String(),
+	}
+	if err != nil {
+		rs.Error = err.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repos[name] = rs
+}
+
+// Snapshot returns every repo's last recorded sync, for JSON encoding.
+func (s *Status) Snapshot() []RepoSync {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]RepoSync, 0, len(s.repos))
+	for _, rs := range s.repos {
+		out = append(out, rs)
+	}
+	return out
+}
+
+// OldestSyncAge returns how long it's been since the least-recently-synced
+// repo was last recorded, and false if no repo has synced yet. This is the
+// worst case across the fleet, so it's what should page someone if syncing
+// has stalled.
+func (s *Status) OldestSyncAge() (time.Duration, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.repos) == 0 {
+		return 0, false
+	}
+
+	oldest := time.Now()
+	for _, rs := range s.repos {
+		if rs.LastSync.Before(oldest) {
+			oldest = rs.LastSync
+		}
+	}
+	return time.Since(oldest), true
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"go-get-repos/daemon"
+	"go-get-repos/gitbackend"
+	"go-get-repos/providers"
+	"go-get-repos/util"
+)
+
+// defaultPollInterval is used when neither -poll-interval nor the creds
+// file's poll_interval says otherwise.
+const defaultPollInterval = time.Hour
+
+// runDaemon keeps re-syncing repos on a schedule instead of exiting after
+// one pass, optionally serving /status and /metrics over HTTP.
+func runDaemon(cfg Config, backend gitbackend.Backend, dest providers.Provider) {
+	interval := resolvePollInterval(cfg)
+	util.PrintHeader(fmt.Sprintf("Starting daemon, polling every %v", interval))
+
+	status := daemon.NewStatus()
+
+	if *httpAddr != "" {
+		go func() {
+			if err := daemon.Serve(*httpAddr, status); err != nil {
+				log.Printf("daemon: http server stopped: %v", err)
+			}
+		}()
+	}
+
+	for {
+		if err := syncOnce(cfg, backend, status, dest); err != nil {
+			log.Println(err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// resolvePollInterval picks -poll-interval, falling back to the creds
+// file's poll_interval, falling back to defaultPollInterval.
+func resolvePollInterval(cfg Config) time.Duration {
+	if *pollInterval > 0 {
+		return *pollInterval
+	}
+	if cfg.PollInterval != "" {
+		if d, err := time.ParseDuration(cfg.PollInterval); err == nil {
+			return d
+		}
+	}
+	return defaultPollInterval
+}
+
+// syncOnce runs exactly one clone+update pass, the same work main does for
+// a one-shot run except that the update step skips repos whose local HEAD
+// is already current, recording results to status and updating RepoCount.
+func syncOnce(cfg Config, backend gitbackend.Backend, status *daemon.Status, dest providers.Provider) error {
+	backupDirContents, err := readBackupDirectory(cfg)
+	if err != nil {
+		return err
+	}
+	backupDirFiles := getBackupDirectoryNames(backupDirContents)
+
+	availableRepos, err := readAllProviderRepos(cfg)
+	if err != nil {
+		return err
+	}
+	daemon.RepoCount.Set(float64(len(availableRepos)))
+
+	cache := NewRepoCache(cfg, availableRepos, *includeArchived)
+
+	reposToClone := cache.ReposToClone(backupDirFiles)
+	numCloned, err := cloneNonBackedupRepos(reposToClone, cfg, backend, status, dest)
+	if err != nil {
+		log.Println(err)
+	}
+	fmt.Printf("Number of repositories cloned, %v\n", numCloned)
+
+	numUpdated, err := updateAllCachedRepos(cfg, availableRepos, cache, backend, status, true, dest)
+	if err != nil {
+		log.Println(err)
+	}
+	fmt.Printf("Number of repositories updated, %v\n", numUpdated)
+
+	if age, ok := status.OldestSyncAge(); ok {
+		daemon.LastSyncAgeSeconds.Set(age.Seconds())
+	}
+
+	return nil
+}
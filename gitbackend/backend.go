@@ -0,0 +1,76 @@
+// Package gitbackend abstracts how a repo actually gets cloned/fetched to
+// disk, so the rest of the tool doesn't care whether that happens by
+// shelling out to a system `git` binary or via a pure-Go implementation.
+package gitbackend
+
+import (
+	"fmt"
+	"time"
+)
+
+// CloneRequest describes a single clone to perform.
+type CloneRequest struct {
+	// URL is the bare clone URL, with no credentials embedded.
+	URL string
+	// CloneURL is URL with credentials embedded, as the cli backend needs
+	// since the system git binary has no way to take auth in-process.
+	CloneURL string
+	// Token authenticates the request; used by backends that support
+	// in-process auth instead of embedding it in the URL.
+	Token string
+	// Dir is the full destination directory for the clone.
+	Dir string
+	// Mirror clones all refs (branches, tags, PR refs) instead of just a
+	// working copy of the default branch, for true backups.
+	Mirror bool
+}
+
+// FetchRequest describes a single fetch of an already-cloned repo.
+type FetchRequest struct {
+	// Dir is the repo's local directory.
+	Dir string
+	// Token authenticates the request, see CloneRequest.Token.
+	Token string
+	// Mirror updates every ref, matching a repo that was cloned with
+	// CloneRequest.Mirror set. The cli backend also prunes refs deleted
+	// upstream; the go-git backend does not.
+	Mirror bool
+}
+
+// PushRequest describes a mirror-push of an already-cloned repo out to a
+// second remote.
+type PushRequest struct {
+	// Dir is the repo's local directory.
+	Dir string
+	// URL is the bare destination URL, with no credentials embedded.
+	URL string
+	// CloneURL is URL with credentials embedded, see CloneRequest.CloneURL.
+	CloneURL string
+	// Token authenticates the request, see CloneRequest.Token.
+	Token string
+}
+
+// Backend performs the actual clone/fetch work for a repo.
+type Backend interface {
+	Name() string
+	Clone(req CloneRequest) error
+	Fetch(req FetchRequest) error
+	// HeadTime returns the commit time of dir's HEAD, so callers can skip
+	// fetching repos that haven't been pushed to since the last sync.
+	HeadTime(dir string) (time.Time, error)
+	// Push mirror-pushes every ref in dir to req's destination, for
+	// cross-forge mirroring.
+	Push(req PushRequest) error
+}
+
+// New returns the Backend named by name ("cli" or "go-git").
+func New(name string) (Backend, error) {
+	switch name {
+	case "", "cli":
+		return NewCLIBackend(), nil
+	case "go-git":
+		return NewGoGitBackend(), nil
+	default:
+		return nil, fmt.Errorf("gitbackend: unknown backend %q", name)
+	}
+}
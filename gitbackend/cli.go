@@ -0,0 +1,62 @@
+package gitbackend
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// CLIBackend shells out to a system `git` binary. This is the original
+// behavior of the tool: it requires git to be installed, and embeds
+// credentials straight into the clone URL (which git then persists into
+// the repo's .git/config).
+type CLIBackend struct{}
+
+func NewCLIBackend() *CLIBackend {
+	return &CLIBackend{}
+}
+
+func (b *CLIBackend) Name() string {
+	return "cli"
+}
+
+func (b *CLIBackend) Clone(req CloneRequest) error {
+	args := []string{"clone"}
+	if req.Mirror {
+		args = append(args, "--mirror")
+	}
+	args = append(args, req.CloneURL, req.Dir)
+
+	cmd := exec.Command("git", args...)
+	return cmd.Run()
+}
+
+func (b *CLIBackend) Fetch(req FetchRequest) error {
+	var cmd *exec.Cmd
+	if req.Mirror {
+		cmd = exec.Command("git", "remote", "update", "--prune")
+	} else {
+		cmd = exec.Command("git", "fetch")
+	}
+	cmd.Dir = req.Dir
+	return cmd.Run()
+}
+
+func (b *CLIBackend) Push(req PushRequest) error {
+	cmd := exec.Command("git", "push", "--mirror", req.CloneURL)
+	cmd.Dir = req.Dir
+	return cmd.Run()
+}
+
+func (b *CLIBackend) HeadTime(dir string) (time.Time, error) {
+	cmd := exec.Command("git", "log", "-1", "--format=%cI")
+	cmd.Dir = dir
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, strings.TrimSpace(out.String()))
+}
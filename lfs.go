@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+)
+
+// fetchLFSIfPresent runs `git lfs fetch --all` in dir when the repo's
+// .gitattributes declares an LFS filter, so large binary assets actually
+// end up in the backup instead of being left as pointer files.
+//
+// .gitattributes is read via `git cat-file`, not the working tree, so
+// this works for a mirror/bare clone (which has no working tree to read
+// a plain file from) the same as it does for a regular clone.
+func fetchLFSIfPresent(dir string) error {
+	cmd := exec.Command("git", "cat-file", "-p", "HEAD:.gitattributes")
+	cmd.Dir = dir
+
+	var attrs bytes.Buffer
+	cmd.Stdout = &attrs
+	if err := cmd.Run(); err != nil {
+		// No .gitattributes at HEAD (or no commits yet) - nothing to do.
+		return nil
+	}
+	if !bytes.Contains(attrs.Bytes(), []byte("filter=lfs")) {
+		return nil
+	}
+
+	fetch := exec.Command("git", "lfs", "fetch", "--all")
+	fetch.Dir = dir
+	return fetch.Run()
+}
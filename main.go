@@ -6,19 +6,41 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"github.com/google/go-github/github"
+	"go-get-repos/daemon"
+	"go-get-repos/gitbackend"
+	"go-get-repos/providers"
 	"go-get-repos/util"
-	"golang.org/x/oauth2"
 	"io/ioutil"
 	"log"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/sync/errgroup"
 )
 
 var creds *string
+var parallel *int
+var backendName *string
+var mirror *bool
+var includeArchived *bool
+var daemonMode *bool
+var pollInterval *time.Duration
+var httpAddr *string
 
 func init() {
 	creds = flag.String("creds", "", "Github Credentials")
+	parallel = flag.Int("parallel", runtime.NumCPU(), "Number of repos to clone/fetch concurrently")
+	backendName = flag.String("backend", "cli", "Clone/fetch backend to use: cli or go-git")
+	mirror = flag.Bool("mirror", false, "Mirror-clone repos (all refs, branches, tags) instead of a working copy")
+	includeArchived = flag.Bool("include-archived", false, "Back up archived/disabled repos too")
+	daemonMode = flag.Bool("daemon", false, "Keep running, re-syncing repos on a schedule instead of exiting after one pass")
+	pollInterval = flag.Duration("poll-interval", 0, "How often to re-sync in daemon mode (default 1h, or poll_interval from creds)")
+	httpAddr = flag.String("http", "", "Address to serve /status and /metrics on in daemon mode, e.g. :8080")
 }
 
 func main() {
@@ -30,15 +52,32 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Get Token from .creds
-	vars, err := loadVars()
+	// Get config from .creds
+	cfg, err := loadConfig()
 	if err != nil {
 		log.Fatal(err)
 		return
 	}
 
+	backend, err := gitbackend.New(*backendName)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	dest, err := destinationProvider(cfg)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	if *daemonMode {
+		runDaemon(cfg, backend, dest)
+		return
+	}
+
 	// Get all existing repos in backup directory
-	backupDirContents, err := readBackupDirectory(vars)
+	backupDirContents, err := readBackupDirectory(cfg)
 	if err != nil {
 		log.Fatal(err)
 		return
@@ -47,14 +86,8 @@ func main() {
 	fmt.Printf("Current Directory Contents: \n")
 	util.PrintList(backupDirFiles)
 
-	// Get all repos from Github
-	var availableRepos []github.Repository
-	if vars.Affiliation == "" {
-		availableRepos, err = readPersonalGithubRepos(vars)
-	} else {
-		availableRepos, err = readOrganizationGithubRepos(vars)
-	}
-
+	// Get all repos from every configured provider
+	availableRepos, err := readAllProviderRepos(cfg)
 	if err != nil {
 		log.Fatal(err)
 		return
@@ -62,52 +95,79 @@ func main() {
 
 	fmt.Printf("Number of repositories to process: %v\n", len(availableRepos))
 
+	cache := NewRepoCache(cfg, availableRepos, *includeArchived)
+
 	// Xor operation to determine what to clone, and to clone
-	reposToClone := getReposToClone(backupDirFiles, availableRepos)
+	reposToClone := cache.ReposToClone(backupDirFiles)
 	fmt.Printf("Number of repositories to clone, first: %v\n", len(reposToClone))
 
 	// For all repos, do a git fetch
-	numProcessed, err := cloneNonBackedupRepos(reposToClone, vars)
+	numProcessed, err := cloneNonBackedupRepos(reposToClone, cfg, backend, nil, dest)
 	if err != nil {
-		log.Fatal(err)
+		log.Println(err)
 	}
 	fmt.Printf("Number of repositories cloned, %v\n", numProcessed)
 
 	// For folder glob, do a git fetch on each one
-	numProcessed, err = updateAllCachedRepos(vars)
+	numProcessed, err = updateAllCachedRepos(cfg, availableRepos, cache, backend, nil, false, dest)
 	if err != nil {
-		log.Fatal(err)
+		log.Println(err)
 	}
 	fmt.Printf("Number of repositories updated, %v\n", numProcessed)
 }
 
-type GHVars struct {
-	Token       string   `json:"token"`
-	Types       []string `json:"types"` // public, internal, private
-	Affiliation string   `json:"org"`
-	BackupDir   string   `json:"backup-dir"`
+// Config is the shape of the JSON creds file. It can declare more than one
+// provider source (a Github org, a Gitlab group, a self-hosted Gitea, ...)
+// and all of them are backed up into the same BackupDir.
+type Config struct {
+	BackupDir string             `json:"backup-dir"`
+	Providers []providers.Config `json:"providers"`
+	Mirror    bool               `json:"mirror"`
+	Include   []string           `json:"include"`
+	Exclude   []string           `json:"exclude"`
+	// PollInterval is a Go duration string (e.g. "30m"), used in daemon
+	// mode when -poll-interval isn't passed on the command line.
+	PollInterval string `json:"poll_interval"`
+	// Destination is a second provider that every backed-up repo is also
+	// mirror-pushed to, for cross-forge DR. Nil disables mirroring.
+	Destination *providers.Config `json:"destination"`
 }
 
-func loadVars() (GHVars, error) {
+// destinationProvider builds the Provider for cfg.Destination, or nil if
+// mirroring to a second provider isn't configured.
+func destinationProvider(cfg Config) (providers.Provider, error) {
+	if cfg.Destination == nil {
+		return nil, nil
+	}
+	return providers.New(*cfg.Destination)
+}
+
+// mirrorEnabled reports whether mirror mode was requested, either on the
+// command line or in the creds file.
+func mirrorEnabled(cfg Config) bool {
+	return *mirror || cfg.Mirror
+}
+
+func loadConfig() (Config, error) {
 	util.PrintHeader(fmt.Sprintf("Loading Creds from %v", creds))
-	var vars GHVars
+	var cfg Config
 
 	_, err := os.Stat(*creds)
 	if errors.Is(err, os.ErrNotExist) {
-		return vars, errors.New(fmt.Sprintf("File, %s, for settings, does not exist!", *creds))
+		return cfg, errors.New(fmt.Sprintf("File, %s, for settings, does not exist!", *creds))
 	}
 
 	contents, err := ioutil.ReadFile(*creds)
-	err = json.Unmarshal(contents, &vars)
+	err = json.Unmarshal(contents, &cfg)
 	if err != nil {
-		return vars, err
+		return cfg, err
 	}
-	return vars, err
+	return cfg, err
 }
 
-func readBackupDirectory(vars GHVars) ([]os.FileInfo, error) {
-	util.PrintHeader(fmt.Sprintf("Reading backup directory: %s", vars.BackupDir))
-	files, err := ioutil.ReadDir(vars.BackupDir)
+func readBackupDirectory(cfg Config) ([]os.FileInfo, error) {
+	util.PrintHeader(fmt.Sprintf("Reading backup directory: %s", cfg.BackupDir))
+	files, err := ioutil.ReadDir(cfg.BackupDir)
 	return files, err
 }
 
@@ -119,131 +179,168 @@ func getBackupDirectoryNames(files []os.FileInfo) []string {
 	return returnFiles
 }
 
-func getGithubClient(vars GHVars) *github.Client {
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: vars.Token},
-	)
-	tc := oauth2.NewClient(context.Background(), ts)
-
-	client := github.NewClient(tc)
-
-	return client
-}
-
-func readPersonalGithubRepos(vars GHVars) ([]github.Repository, error) {
-	util.PrintHeader("Reading PERSONAL Github Repos")
-	ghRepos := make([]github.Repository, 0, 20)
-	page := 1
-	for {
-		client := getGithubClient(vars)
-		lopt := github.ListOptions{PerPage: 100, Page: page}
-
-		opt := &github.RepositoryListOptions{Affiliation: "owner", ListOptions: lopt}
-		repos, _, err := client.Repositories.List(context.Background(), "", opt)
+// readAllProviderRepos builds a Provider for every configured source and
+// concatenates what each of them sees.
+func readAllProviderRepos(cfg Config) ([]providers.Repo, error) {
+	var allRepos []providers.Repo
 
+	for _, providerCfg := range cfg.Providers {
+		provider, err := providers.New(providerCfg)
 		if err != nil {
-			return ghRepos, err
-		}
-
-		for x := 0; x < len(repos); x++ {
-			ghRepos = append(ghRepos, *repos[x])
+			return allRepos, err
 		}
 
-		if len(repos) == 0 {
-			break
+		util.PrintHeader(fmt.Sprintf("Reading repos from %v", provider.Name()))
+		repos, err := provider.ListRepos(context.Background())
+		if err != nil {
+			return allRepos, err
 		}
-		page += 1
+		allRepos = append(allRepos, repos...)
 	}
-	return ghRepos, nil
+
+	return allRepos, nil
 }
 
-func readOrganizationGithubRepos(vars GHVars) ([]github.Repository, error) {
-	util.PrintHeader("Reading ORG Github Repos")
-	ghRepos := make([]github.Repository, 0, 20)
+// repoDirName returns the unique on-disk directory name for repo. It's
+// derived from FullName (owner/name), not the bare Name, so two providers
+// (or two owners on the same provider) that happen to share a repo name
+// don't clobber each other's clone directory.
+func repoDirName(repo providers.Repo) string {
+	return strings.ReplaceAll(repo.FullName, "/", "_")
+}
 
-	for _, tpe := range vars.Types {
-		page := 1
-		for {
-			client := getGithubClient(vars)
-			lopt := github.ListOptions{PerPage: 100, Page: page}
+func cloneNonBackedupRepos(repos []providers.Repo, cfg Config, backend gitbackend.Backend, status *daemon.Status, dest providers.Provider) (int, error) {
+	util.PrintHeader(fmt.Sprintf("Cloning all non-cached repos, number to process: %v", len(repos)))
 
-			opt := &github.RepositoryListByOrgOptions{
-				Type:        tpe, // "Private", Or Internal
-				ListOptions: lopt,
-			}
-			repos, _, err := client.Repositories.ListByOrg(context.Background(), "UFGInsurance", opt)
+	bar := progressbar.Default(int64(len(repos)), "cloning")
+	multiErr := &MultiError{}
+	useMirror := mirrorEnabled(cfg)
 
-			if err != nil {
-				return ghRepos, err
-			}
+	group, _ := errgroup.WithContext(context.Background())
+	group.SetLimit(*parallel)
 
-			for x := 0; x < len(repos); x++ {
-				ghRepos = append(ghRepos, *repos[x])
+	for _, repo := range repos {
+		repo := repo
+		group.Go(func() error {
+			fmt.Printf("==> Processing: %v\n", repo.FullName)
+			start := time.Now()
+			dir := filepath.Join(cfg.BackupDir, repoDirName(repo))
+			req := gitbackend.CloneRequest{
+				URL:      repo.URL,
+				CloneURL: repo.CloneURL,
+				Token:    repo.Token,
+				Dir:      dir,
+				Mirror:   useMirror,
 			}
-
-			if len(repos) == 0 {
-				break
+			err := backend.Clone(req)
+			if err != nil {
+				err = fmt.Errorf("clone %s: %w", repo.FullName, err)
+			} else if lfsErr := fetchLFSIfPresent(dir); lfsErr != nil {
+				err = fmt.Errorf("lfs fetch %s: %w", repo.FullName, lfsErr)
+			} else if dest != nil {
+				err = mirrorToDestination(repo, dir, dest, backend)
 			}
-			page += 1
-		}
-	}
-	return ghRepos, nil
-}
-
-func getReposToClone(files []string, repos []github.Repository) []github.Repository {
-	var ghToClone []github.Repository
-	for _, repo := range repos {
-		inCache := false
-		for _, fhave := range files {
-			if fhave == *repo.Name {
-				inCache = true
-				break
+			multiErr.Add(err)
+			if status != nil {
+				status.Record(repo.FullName, time.Since(start), err)
+				daemon.ClonesTotal.Inc()
+				if err != nil {
+					daemon.FailuresTotal.Inc()
+				}
 			}
-		}
-		if !inCache {
-			ghToClone = append(ghToClone, repo)
-		}
+			_ = bar.Add(1)
+			return nil
+		})
 	}
-	return ghToClone
-}
+	_ = group.Wait()
 
-func cloneNonBackedupRepos(repos []github.Repository, vars GHVars) (int, error) {
-	util.PrintHeader(fmt.Sprintf("Cloning all non-cached repos, number to process: %v", len(repos)))
-	numReposProcessed := 0
-	err := error(nil)
-	for _, repo := range repos {
-		fmt.Printf("==> Processing: %v\n", *repo.Name)
-		fullRepoURI := fmt.Sprintf("https://%v:%v@github.com/%v", *repo.Owner.Login, vars.Token, *repo.FullName)
-		cmd := exec.Command("git", "clone", fullRepoURI)
-		cmd.Dir = vars.BackupDir
-		err := cmd.Run()
-		if err != nil {
-			return numReposProcessed, err
-		}
-		numReposProcessed += 1
-	}
-	return numReposProcessed, err
+	numReposProcessed := len(repos) - len(multiErr.errors)
+	return numReposProcessed, multiErr.ErrOrNil()
 }
 
-func updateAllCachedRepos(vars GHVars) (int, error) {
-	backupDirectoryFiles, err := readBackupDirectory(vars)
+// updateAllCachedRepos fetches every already-cloned repo that passes
+// cache's rules. When staleOnly is set (daemon mode), a repo is skipped
+// if its local HEAD is already at least as new as the provider's
+// last-known pushed_at, and repos are processed most-recently-pushed
+// first.
+func updateAllCachedRepos(cfg Config, knownRepos []providers.Repo, cache *RepoCache, backend gitbackend.Backend, status *daemon.Status, staleOnly bool, dest providers.Provider) (int, error) {
+	backupDirectoryFiles, err := readBackupDirectory(cfg)
 
 	numReposUpdated := 0
 	if err != nil {
 		return numReposUpdated, err
 	}
 
-	util.PrintHeader(fmt.Sprintf("Updating all cached repos, number to process: %v", len(backupDirectoryFiles)))
+	byDirName := make(map[string]providers.Repo, len(knownRepos))
+	for _, repo := range knownRepos {
+		byDirName[repoDirName(repo)] = repo
+	}
 
+	var toUpdate []os.FileInfo
 	for _, repo := range backupDirectoryFiles {
-		fmt.Printf("==> Processing: %v\n", repo.Name())
-		cmd := exec.Command("git", "fetch")
-		cmd.Dir = fmt.Sprintf("%s/%s", vars.BackupDir, repo.Name())
-		err := cmd.Run()
-		if err != nil {
-			return numReposUpdated, err
+		if cache.ShouldUpdate(repo.Name()) {
+			toUpdate = append(toUpdate, repo)
 		}
-		numReposUpdated += 1
 	}
-	return numReposUpdated, err
+	if staleOnly {
+		sort.Slice(toUpdate, func(i, j int) bool {
+			return byDirName[toUpdate[i].Name()].PushedAt.After(byDirName[toUpdate[j].Name()].PushedAt)
+		})
+	}
+
+	util.PrintHeader(fmt.Sprintf("Updating all cached repos, number to process: %v", len(toUpdate)))
+
+	bar := progressbar.Default(int64(len(toUpdate)), "fetching")
+	multiErr := &MultiError{}
+	useMirror := mirrorEnabled(cfg)
+
+	group, _ := errgroup.WithContext(context.Background())
+	group.SetLimit(*parallel)
+
+	for _, repo := range toUpdate {
+		repo := repo
+		group.Go(func() error {
+			dir := filepath.Join(cfg.BackupDir, repo.Name())
+
+			if staleOnly {
+				if known, ok := byDirName[repo.Name()]; ok {
+					if headTime, err := backend.HeadTime(dir); err == nil && !headTime.Before(known.PushedAt) {
+						fmt.Printf("==> Skipping (up to date): %v\n", repo.Name())
+						_ = bar.Add(1)
+						return nil
+					}
+				}
+			}
+
+			fmt.Printf("==> Processing: %v\n", repo.Name())
+			start := time.Now()
+			req := gitbackend.FetchRequest{
+				Dir:    dir,
+				Token:  byDirName[repo.Name()].Token,
+				Mirror: useMirror,
+			}
+			err := backend.Fetch(req)
+			if err != nil {
+				err = fmt.Errorf("fetch %s: %w", repo.Name(), err)
+			} else if lfsErr := fetchLFSIfPresent(dir); lfsErr != nil {
+				err = fmt.Errorf("lfs fetch %s: %w", repo.Name(), lfsErr)
+			} else if dest != nil {
+				err = mirrorToDestination(byDirName[repo.Name()], dir, dest, backend)
+			}
+			multiErr.Add(err)
+			if status != nil {
+				status.Record(repo.Name(), time.Since(start), err)
+				daemon.FetchesTotal.Inc()
+				if err != nil {
+					daemon.FailuresTotal.Inc()
+				}
+			}
+			_ = bar.Add(1)
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	numReposUpdated = len(toUpdate) - len(multiErr.errors)
+	return numReposUpdated, multiErr.ErrOrNil()
 }
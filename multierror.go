@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MultiError collects the errors produced by a batch of worker-pool jobs
+// (one per repo) so a single bad repo doesn't abort the rest of the run.
+// It is safe for concurrent use.
+type MultiError struct {
+	mu     sync.Mutex
+	errors []error
+}
+
+// Add records err, ignoring it if err is nil.
+func (m *MultiError) Add(err error) {
+	if err == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors = append(m.errors, err)
+}
+
+// ErrOrNil returns m if it recorded at least one error, otherwise nil, so
+// it can be returned directly from a function's (..., error) result.
+func (m *MultiError) ErrOrNil() error {
+	if m == nil || len(m.errors) == 0 {
+		return nil
+	}
+	return m
+}
+
+func (m *MultiError) Error() string {
+	lines := make([]string, len(m.errors))
+	for ix, err := range m.errors {
+		lines[ix] = err.Error()
+	}
+	return fmt.Sprintf("%d repo(s) failed:\n%s", len(m.errors), strings.Join(lines, "\n"))
+}
@@ -0,0 +1,88 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ktrysmt/go-bitbucket"
+)
+
+// BitbucketProvider lists repos in a Bitbucket Cloud workspace.
+type BitbucketProvider struct {
+	cfg Config
+}
+
+func NewBitbucketProvider(cfg Config) *BitbucketProvider {
+	return &BitbucketProvider{cfg: cfg}
+}
+
+func (p *BitbucketProvider) Name() string {
+	return "bitbucket"
+}
+
+func (p *BitbucketProvider) client() *bitbucket.Client {
+	return bitbucket.NewOAuthbearerToken(p.cfg.Token)
+}
+
+func (p *BitbucketProvider) ListRepos(ctx context.Context) ([]Repo, error) {
+	client := p.client()
+
+	res, err := client.Repositories.ListForAccount(&bitbucket.RepositoriesOptions{
+		Owner: p.cfg.Affiliation,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []Repo
+	for _, r := range res.Items {
+		r := r
+		repos = append(repos, p.finish(toBitbucketRepo(p.cfg.Affiliation, &r)))
+	}
+	return repos, nil
+}
+
+// EnsureRepo looks up name in this provider's workspace and creates it if
+// it isn't there yet.
+func (p *BitbucketProvider) EnsureRepo(ctx context.Context, name string) (Repo, error) {
+	client := p.client()
+	opt := &bitbucket.RepositoryOptions{Owner: p.cfg.Affiliation, RepoSlug: name}
+
+	if r, err := client.Repositories.Repository.Get(opt); err == nil {
+		return p.finish(toBitbucketRepo(p.cfg.Affiliation, r)), nil
+	}
+
+	r, err := client.Repositories.Repository.Create(opt)
+	if err != nil {
+		return Repo{}, fmt.Errorf("create bitbucket repo %s: %w", name, err)
+	}
+	return p.finish(toBitbucketRepo(p.cfg.Affiliation, r)), nil
+}
+
+// toBitbucketRepo mirrors the Repo fields ListRepos fills in from a
+// Repository, so EnsureRepo and ListRepos stay in sync.
+func toBitbucketRepo(owner string, r *bitbucket.Repository) Repo {
+	repo := Repo{
+		Owner:    owner,
+		Name:     r.Name,
+		FullName: r.Full_name,
+	}
+	if pushedAt, err := time.Parse(time.RFC3339, r.UpdatedOn); err == nil {
+		repo.PushedAt = pushedAt
+	}
+	return repo
+}
+
+func (p *BitbucketProvider) CloneURL(repo Repo) string {
+	return fmt.Sprintf("https://x-token-auth:%v@bitbucket.org/%v", p.cfg.Token, repo.FullName)
+}
+
+// finish fills in the URL/CloneURL/Token fields every repo out of this
+// provider needs.
+func (p *BitbucketProvider) finish(repo Repo) Repo {
+	repo.URL = fmt.Sprintf("https://bitbucket.org/%v", repo.FullName)
+	repo.CloneURL = p.CloneURL(repo)
+	repo.Token = p.cfg.Token
+	return repo
+}
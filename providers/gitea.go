@@ -0,0 +1,123 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/sdk/gitea"
+)
+
+// GiteaProvider lists repos owned by a user or org on a (typically
+// self-hosted) Gitea instance.
+type GiteaProvider struct {
+	cfg Config
+}
+
+func NewGiteaProvider(cfg Config) *GiteaProvider {
+	return &GiteaProvider{cfg: cfg}
+}
+
+func (p *GiteaProvider) Name() string {
+	return "gitea"
+}
+
+func (p *GiteaProvider) client() (*gitea.Client, error) {
+	return gitea.NewClient(p.cfg.BaseURL, gitea.SetToken(p.cfg.Token))
+}
+
+func (p *GiteaProvider) ListRepos(ctx context.Context) ([]Repo, error) {
+	client, err := p.client()
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []Repo
+	page := 1
+	for {
+		listOpt := gitea.ListOptions{PageSize: 50, Page: page}
+
+		var ghRepos []*gitea.Repository
+		if p.cfg.Affiliation == "" {
+			ghRepos, _, err = client.ListMyRepos(gitea.ListReposOptions{ListOptions: listOpt})
+		} else {
+			ghRepos, _, err = client.ListOrgRepos(p.cfg.Affiliation, gitea.ListOrgReposOptions{ListOptions: listOpt})
+		}
+		if err != nil {
+			return repos, err
+		}
+
+		for _, r := range ghRepos {
+			repos = append(repos, p.finish(toGiteaRepo(r)))
+		}
+
+		if len(ghRepos) == 0 {
+			break
+		}
+		page += 1
+	}
+	return repos, nil
+}
+
+// EnsureRepo looks up name in this provider's org (or personal account, if
+// Affiliation is unset) and creates it if it isn't there yet.
+func (p *GiteaProvider) EnsureRepo(ctx context.Context, name string) (Repo, error) {
+	client, err := p.client()
+	if err != nil {
+		return Repo{}, err
+	}
+
+	owner := p.cfg.Affiliation
+	if owner == "" {
+		me, _, err := client.GetMyUserInfo()
+		if err != nil {
+			return Repo{}, fmt.Errorf("resolve gitea user: %w", err)
+		}
+		owner = me.UserName
+	}
+	if r, _, err := client.GetRepo(owner, name); err == nil {
+		return p.finish(toGiteaRepo(r)), nil
+	}
+
+	var r *gitea.Repository
+	if p.cfg.Affiliation == "" {
+		r, _, err = client.CreateRepo(gitea.CreateRepoOption{Name: name})
+	} else {
+		r, _, err = client.CreateOrgRepo(p.cfg.Affiliation, gitea.CreateRepoOption{Name: name})
+	}
+	if err != nil {
+		return Repo{}, fmt.Errorf("create gitea repo %s: %w", name, err)
+	}
+	return p.finish(toGiteaRepo(r)), nil
+}
+
+// toGiteaRepo mirrors the Repo fields ListRepos fills in from a
+// Repository, so EnsureRepo and ListRepos stay in sync.
+func toGiteaRepo(r *gitea.Repository) Repo {
+	return Repo{
+		Owner:    r.Owner.UserName,
+		Name:     r.Name,
+		FullName: r.FullName,
+		Archived: r.Archived,
+		PushedAt: r.Updated,
+	}
+}
+
+// host returns the bare host:port this provider talks to, with any
+// scheme p.cfg.BaseURL carries (required by gitea.NewClient) stripped
+// back off, since CloneURL/finish add their own "https://" prefix.
+func (p *GiteaProvider) host() string {
+	return stripScheme(p.cfg.BaseURL)
+}
+
+func (p *GiteaProvider) CloneURL(repo Repo) string {
+	return fmt.Sprintf("https://%v:%v@%v/%v", repo.Owner, p.cfg.Token, p.host(), repo.FullName)
+}
+
+// finish fills in the URL/CloneURL/Token fields every repo out of this
+// provider needs.
+func (p *GiteaProvider) finish(repo Repo) Repo {
+	repo.URL = fmt.Sprintf("https://%v/%v", p.host(), repo.FullName)
+	repo.CloneURL = p.CloneURL(repo)
+	repo.Token = p.cfg.Token
+	return repo
+}
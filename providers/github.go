@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+)
+
+// GithubProvider lists repos from github.com, either for a personal
+// account (Affiliation == "") or for an organization.
+type GithubProvider struct {
+	cfg Config
+}
+
+func NewGithubProvider(cfg Config) *GithubProvider {
+	return &GithubProvider{cfg: cfg}
+}
+
+func (p *GithubProvider) Name() string {
+	return "github"
+}
+
+func (p *GithubProvider) client() *github.Client {
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: p.cfg.Token},
+	)
+	tc := oauth2.NewClient(context.Background(), ts)
+
+	return github.NewClient(tc)
+}
+
+func (p *GithubProvider) ListRepos(ctx context.Context) ([]Repo, error) {
+	if p.cfg.Affiliation == "" {
+		return p.listPersonalRepos(ctx)
+	}
+	return p.listOrganizationRepos(ctx)
+}
+
+func (p *GithubProvider) listPersonalRepos(ctx context.Context) ([]Repo, error) {
+	var repos []Repo
+	page := 1
+	for {
+		client := p.client()
+		lopt := github.ListOptions{PerPage: 100, Page: page}
+		opt := &github.RepositoryListOptions{Affiliation: "owner", ListOptions: lopt}
+
+		ghRepos, _, err := client.Repositories.List(ctx, "", opt)
+		if err != nil {
+			return repos, err
+		}
+
+		for _, r := range ghRepos {
+			repos = append(repos, p.finish(toRepo(r)))
+		}
+
+		if len(ghRepos) == 0 {
+			break
+		}
+		page += 1
+	}
+	return repos, nil
+}
+
+func (p *GithubProvider) listOrganizationRepos(ctx context.Context) ([]Repo, error) {
+	var repos []Repo
+
+	for _, tpe := range p.cfg.Types {
+		page := 1
+		for {
+			client := p.client()
+			lopt := github.ListOptions{PerPage: 100, Page: page}
+
+			opt := &github.RepositoryListByOrgOptions{
+				Type:        tpe,
+				ListOptions: lopt,
+			}
+			ghRepos, _, err := client.Repositories.ListByOrg(ctx, p.cfg.Affiliation, opt)
+			if err != nil {
+				return repos, err
+			}
+
+			for _, r := range ghRepos {
+				repos = append(repos, p.finish(toRepo(r)))
+			}
+
+			if len(ghRepos) == 0 {
+				break
+			}
+			page += 1
+		}
+	}
+	return repos, nil
+}
+
+// EnsureRepo looks up name in this provider's org (or personal account, if
+// Affiliation is unset) and creates it if it isn't there yet.
+func (p *GithubProvider) EnsureRepo(ctx context.Context, name string) (Repo, error) {
+	client := p.client()
+
+	owner := p.cfg.Affiliation
+	if owner == "" {
+		me, _, err := client.Users.Get(ctx, "")
+		if err != nil {
+			return Repo{}, fmt.Errorf("resolve github user: %w", err)
+		}
+		owner = me.GetLogin()
+	}
+	if ghRepo, _, err := client.Repositories.Get(ctx, owner, name); err == nil {
+		return p.finish(toRepo(ghRepo)), nil
+	}
+
+	ghRepo, _, err := client.Repositories.Create(ctx, p.cfg.Affiliation, &github.Repository{Name: &name})
+	if err != nil {
+		return Repo{}, fmt.Errorf("create github repo %s: %w", name, err)
+	}
+	return p.finish(toRepo(ghRepo)), nil
+}
+
+func (p *GithubProvider) CloneURL(repo Repo) string {
+	return fmt.Sprintf("https://%v:%v@github.com/%v", repo.Owner, p.cfg.Token, repo.FullName)
+}
+
+// finish fills in the URL/CloneURL/Token fields that every repo coming out
+// of this provider needs, regardless of which listing call produced it.
+func (p *GithubProvider) finish(repo Repo) Repo {
+	repo.URL = fmt.Sprintf("https://github.com/%v", repo.FullName)
+	repo.CloneURL = p.CloneURL(repo)
+	repo.Token = p.cfg.Token
+	return repo
+}
+
+func toRepo(r *github.Repository) Repo {
+	repo := Repo{
+		Name:     r.GetName(),
+		FullName: r.GetFullName(),
+		Archived: r.GetArchived(),
+		PushedAt: r.GetPushedAt().Time,
+	}
+	if r.Owner != nil {
+		repo.Owner = r.Owner.GetLogin()
+	}
+	return repo
+}
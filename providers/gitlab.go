@@ -0,0 +1,138 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// GitlabProvider lists repos ("projects" in Gitlab parlance) owned by a
+// user or group, against gitlab.com or a self-hosted instance via BaseURL.
+type GitlabProvider struct {
+	cfg Config
+}
+
+func NewGitlabProvider(cfg Config) *GitlabProvider {
+	return &GitlabProvider{cfg: cfg}
+}
+
+func (p *GitlabProvider) Name() string {
+	return "gitlab"
+}
+
+func (p *GitlabProvider) client() (*gitlab.Client, error) {
+	if p.cfg.BaseURL != "" {
+		return gitlab.NewClient(p.cfg.Token, gitlab.WithBaseURL(p.cfg.BaseURL))
+	}
+	return gitlab.NewClient(p.cfg.Token)
+}
+
+func (p *GitlabProvider) ListRepos(ctx context.Context) ([]Repo, error) {
+	client, err := p.client()
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []Repo
+	page := 1
+	for {
+		opt := &gitlab.ListProjectsOptions{
+			ListOptions: gitlab.ListOptions{PerPage: 100, Page: page},
+		}
+
+		var projects []*gitlab.Project
+		if p.cfg.Affiliation == "" {
+			opt.Owned = gitlab.Bool(true)
+			projects, _, err = client.Projects.ListProjects(opt, gitlab.WithContext(ctx))
+		} else {
+			groupOpt := &gitlab.ListGroupProjectsOptions{ListOptions: opt.ListOptions}
+			projects, _, err = client.Groups.ListGroupProjects(p.cfg.Affiliation, groupOpt, gitlab.WithContext(ctx))
+		}
+		if err != nil {
+			return repos, err
+		}
+
+		for _, proj := range projects {
+			repos = append(repos, p.finish(toGitlabRepo(proj)))
+		}
+
+		if len(projects) == 0 {
+			break
+		}
+		page += 1
+	}
+	return repos, nil
+}
+
+// EnsureRepo looks up name under this provider's namespace and creates it
+// if it isn't there yet.
+func (p *GitlabProvider) EnsureRepo(ctx context.Context, name string) (Repo, error) {
+	client, err := p.client()
+	if err != nil {
+		return Repo{}, err
+	}
+
+	namespace := p.cfg.Affiliation
+	if namespace == "" {
+		me, _, err := client.Users.CurrentUser(gitlab.WithContext(ctx))
+		if err != nil {
+			return Repo{}, fmt.Errorf("resolve gitlab user: %w", err)
+		}
+		namespace = me.Username
+	}
+	path := namespace + "/" + name
+	if proj, _, err := client.Projects.GetProject(path, nil, gitlab.WithContext(ctx)); err == nil {
+		return p.finish(toGitlabRepo(proj)), nil
+	}
+
+	opt := &gitlab.CreateProjectOptions{Name: &name}
+	if p.cfg.Affiliation != "" {
+		group, _, err := client.Groups.GetGroup(p.cfg.Affiliation, nil, gitlab.WithContext(ctx))
+		if err != nil {
+			return Repo{}, fmt.Errorf("resolve gitlab group %s: %w", p.cfg.Affiliation, err)
+		}
+		opt.NamespaceID = &group.ID
+	}
+
+	proj, _, err := client.Projects.CreateProject(opt, gitlab.WithContext(ctx))
+	if err != nil {
+		return Repo{}, fmt.Errorf("create gitlab project %s: %w", name, err)
+	}
+	return p.finish(toGitlabRepo(proj)), nil
+}
+
+// toGitlabRepo mirrors the Repo fields ListRepos fills in from a Project,
+// so EnsureRepo and ListRepos stay in sync.
+func toGitlabRepo(proj *gitlab.Project) Repo {
+	repo := Repo{
+		Owner:    proj.Namespace.Path,
+		Name:     proj.Name,
+		FullName: proj.PathWithNamespace,
+		Archived: proj.Archived,
+	}
+	if proj.LastActivityAt != nil {
+		repo.PushedAt = *proj.LastActivityAt
+	}
+	return repo
+}
+
+func (p *GitlabProvider) host() string {
+	if p.cfg.BaseURL != "" {
+		return stripScheme(p.cfg.BaseURL)
+	}
+	return "gitlab.com"
+}
+
+func (p *GitlabProvider) CloneURL(repo Repo) string {
+	return fmt.Sprintf("https://oauth2:%v@%v/%v", p.cfg.Token, p.host(), repo.FullName)
+}
+
+// finish fills in the URL/CloneURL/Token fields every repo out of this
+// provider needs.
+func (p *GitlabProvider) finish(repo Repo) Repo {
+	repo.URL = fmt.Sprintf("https://%v/%v", p.host(), repo.FullName)
+	repo.CloneURL = p.CloneURL(repo)
+	repo.Token = p.cfg.Token
+	return repo
+}
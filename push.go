@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go-get-repos/gitbackend"
+	"go-get-repos/providers"
+)
+
+// mirrorToDestination pushes the repo cloned/fetched at dir out to dest as
+// a mirror, creating the destination repo first if it doesn't exist yet.
+func mirrorToDestination(repo providers.Repo, dir string, dest providers.Provider, backend gitbackend.Backend) error {
+	destRepo, err := dest.EnsureRepo(context.Background(), repo.Name)
+	if err != nil {
+		return fmt.Errorf("ensure destination repo %s: %w", repo.Name, err)
+	}
+
+	req := gitbackend.PushRequest{
+		Dir:      dir,
+		URL:      destRepo.URL,
+		CloneURL: destRepo.CloneURL,
+		Token:    destRepo.Token,
+	}
+	if err := backend.Push(req); err != nil {
+		return fmt.Errorf("push %s to %s: %w", repo.Name, dest.Name(), err)
+	}
+	return nil
+}
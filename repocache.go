@@ -0,0 +1,107 @@
+package main
+
+import (
+	"go-get-repos/providers"
+	"path"
+	"strings"
+)
+
+// RepoCache owns the include/exclude and archived rules so the clone
+// decision (what's missing locally) and the update decision (what's
+// still worth fetching) apply exactly the same filters.
+//
+// Originally-requested "Disabled" filtering is not implemented: none of
+// the four provider SDKs this tool talks to (go-github v17, go-gitlab,
+// go-bitbucket, the gitea SDK) expose a disabled/locked-repo signal on
+// their repository types, so there's nothing for providers.Repo to carry
+// and nothing here to check it against.
+type RepoCache struct {
+	include         []string
+	exclude         []string
+	includeArchived bool
+	byDirName       map[string]providers.Repo
+}
+
+// NewRepoCache indexes repos by their on-disk directory name (repoDirName,
+// not the bare repo name, so same-named repos from different owners or
+// providers don't collide) and captures the filters from cfg.
+func NewRepoCache(cfg Config, repos []providers.Repo, includeArchived bool) *RepoCache {
+	byDirName := make(map[string]providers.Repo, len(repos))
+	for _, repo := range repos {
+		byDirName[repoDirName(repo)] = repo
+	}
+
+	return &RepoCache{
+		include:         cfg.Include,
+		exclude:         cfg.Exclude,
+		includeArchived: includeArchived,
+		byDirName:       byDirName,
+	}
+}
+
+// Allowed reports whether repo passes the include/exclude glob patterns
+// and the archived filter. (No disabled filter: see the RepoCache doc
+// comment.)
+func (c *RepoCache) Allowed(repo providers.Repo) bool {
+	if repo.Archived && !c.includeArchived {
+		return false
+	}
+
+	if len(c.include) > 0 && !matchesAny(c.include, repo) {
+		return false
+	}
+	if matchesAny(c.exclude, repo) {
+		return false
+	}
+	return true
+}
+
+// ReposToClone returns the repos that are allowed and not already present
+// in backupDirFiles.
+func (c *RepoCache) ReposToClone(backupDirFiles []string) []providers.Repo {
+	have := make(map[string]bool, len(backupDirFiles))
+	for _, name := range backupDirFiles {
+		have[name] = true
+	}
+
+	var toClone []providers.Repo
+	for _, repo := range c.byDirName {
+		if have[repoDirName(repo)] {
+			continue
+		}
+		if !c.Allowed(repo) {
+			continue
+		}
+		toClone = append(toClone, repo)
+	}
+	return toClone
+}
+
+// ShouldUpdate reports whether the already-cloned repo directory called
+// dirName should still be fetched. A local directory that no longer
+// corresponds to a known repo (renamed, transferred) is always updated,
+// since there's nothing to filter it against.
+func (c *RepoCache) ShouldUpdate(dirName string) bool {
+	repo, known := c.byDirName[dirName]
+	if !known {
+		return true
+	}
+	return c.Allowed(repo)
+}
+
+// matchesAny reports whether any pattern matches repo. A pattern with no
+// "/" is matched against the repo's bare name, so "archived-*" matches
+// "owner/archived-foo"; a pattern containing "/" is matched against the
+// full "owner/name" instead, as an explicit opt-in to scope by owner.
+func matchesAny(patterns []string, repo providers.Repo) bool {
+	for _, pattern := range patterns {
+		target := repo.Name
+		if strings.Contains(pattern, "/") {
+			target = repo.FullName
+		}
+		if ok, err := path.Match(pattern, target); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}